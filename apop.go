@@ -0,0 +1,29 @@
+package pop3
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var apopTimestampRe = regexp.MustCompile(`<[^>]+>`)
+
+// Apop authenticates using the APOP command (RFC 1939 §7). Unlike the
+// other mechanisms in this package, APOP predates SASL and so is sent as
+// its own command rather than through AuthSASL. It requires the server to
+// have included a timestamp banner (e.g.
+// "<1896.697170952@dbc.mtview.ca.us>") in its greeting, which NewClient
+// captures for this purpose.
+func (c *Client) Apop(name, password string) (err error) {
+	timestamp := apopTimestampRe.FindString(c.greeting)
+	if timestamp == "" {
+		return errors.New("pop3: server greeting did not include an APOP timestamp")
+	}
+
+	digest := fmt.Sprintf("%x", md5.Sum([]byte(timestamp+password)))
+	if _, err = c.Cmd("APOP %s %s\r\n", name, digest); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/md5"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func newTestClientWithGreeting(t *testing.T, greeting string) (*Client, net.Conn) {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+	go serverConn.Write([]byte(greeting + "\r\n"))
+
+	c, err := NewClient(clientConn)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c, serverConn
+}
+
+func TestApopSendsDigestOfTimestampAndPassword(t *testing.T) {
+	timestamp := "<1896.697170952@dbc.mtview.ca.us>"
+	c, server := newTestClientWithGreeting(t, "+OK POP3 server ready "+timestamp)
+	defer server.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(server).ReadString('\n')
+		done <- strings.TrimRight(line, "\r\n")
+		server.Write([]byte("+OK maildrop locked and ready\r\n"))
+	}()
+
+	if err := c.Apop("mrose", "tanstaaf"); err != nil {
+		t.Fatalf("Apop: %v", err)
+	}
+
+	wantDigest := fmt.Sprintf("%x", md5.Sum([]byte(timestamp+"tanstaaf")))
+	want := "APOP mrose " + wantDigest
+	if got := <-done; got != want {
+		t.Fatalf("server received %q, want %q", got, want)
+	}
+}
+
+func TestApopRequiresTimestampInGreeting(t *testing.T) {
+	c, server := newTestClientWithGreeting(t, "+OK POP3 server ready")
+	defer server.Close()
+
+	if err := c.Apop("mrose", "tanstaaf"); err == nil {
+		t.Fatal("Apop err = nil, want an error for a greeting without a timestamp")
+	}
+}
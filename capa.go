@@ -0,0 +1,64 @@
+package pop3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrNotSupported is returned by optional-command wrappers when the server
+// has advertised its capabilities via CAPA but did not list the capability
+// required for the requested command.
+type ErrNotSupported struct {
+	Capability string
+}
+
+func (e *ErrNotSupported) Error() string {
+	return fmt.Sprintf("pop3: server does not support %s", e.Capability)
+}
+
+// Capa issues the CAPA command (RFC 2449), parses the multi-line response
+// and stores the advertised capabilities on the Client. The capability
+// keyword is the first token of each response line; any parameters that
+// follow it (e.g. the mechanism list on a SASL line) are discarded.
+func (c *Client) Capa() (capabilities []string, err error) {
+	if _, err = c.Cmd("%s\r\n", CAPA); err != nil {
+		return
+	}
+
+	lines, err := c.ReadLines()
+	if err != nil {
+		return
+	}
+
+	c.capabilities = make(map[string]bool, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		c.capabilities[fields[0]] = true
+		capabilities = append(capabilities, fields[0])
+	}
+	return
+}
+
+// Supports reports whether the server has advertised the given capability.
+// It returns false both when the capability is absent and when Capa has
+// never been called, since in that case nothing is known about the server.
+func (c *Client) Supports(capability string) bool {
+	return c.capabilities[capability]
+}
+
+// requireCapability returns ErrNotSupported if CAPA has been queried and
+// did not list capability. If CAPA has never been queried, the command is
+// allowed through unconditionally, since many servers support optional
+// commands without advertising CAPA at all.
+func (c *Client) requireCapability(capability string) error {
+	if c.capabilities == nil {
+		return nil
+	}
+	if !c.capabilities[capability] {
+		return &ErrNotSupported{Capability: capability}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package pop3
+
+import "testing"
+
+func TestCapaParsesAndSupports(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "CAPA\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("STLS\r\n"))
+		server.Write([]byte("SASL PLAIN LOGIN\r\n"))
+		server.Write([]byte("UIDL\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	capabilities, err := c.Capa()
+	if err != nil {
+		t.Fatalf("Capa: %v", err)
+	}
+
+	want := []string{"STLS", "SASL", "UIDL"}
+	if len(capabilities) != len(want) {
+		t.Fatalf("capabilities = %v, want %v", capabilities, want)
+	}
+	for i, w := range want {
+		if capabilities[i] != w {
+			t.Fatalf("capabilities[%d] = %q, want %q", i, capabilities[i], w)
+		}
+	}
+
+	if !c.Supports("SASL") {
+		t.Fatal("Supports(\"SASL\") = false, want true")
+	}
+	if c.Supports("PIPELINING") {
+		t.Fatal("Supports(\"PIPELINING\") = true, want false")
+	}
+}
+
+func TestRequireCapabilityUnknownBeforeCapa(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	// CAPA has never been queried, so requireCapability must let the
+	// command through rather than assume the server lacks it.
+	if err := c.requireCapability("UIDL"); err != nil {
+		t.Fatalf("requireCapability before Capa: %v", err)
+	}
+}
+
+func TestRequireCapabilityMissingAfterCapa(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "CAPA\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("UIDL\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	if _, err := c.Capa(); err != nil {
+		t.Fatalf("Capa: %v", err)
+	}
+
+	err := c.requireCapability("PIPELINING")
+	var notSupported *ErrNotSupported
+	if se, ok := err.(*ErrNotSupported); !ok {
+		t.Fatalf("requireCapability err = %v (%T), want *ErrNotSupported", err, err)
+	} else {
+		notSupported = se
+	}
+	if notSupported.Capability != "PIPELINING" {
+		t.Fatalf("Capability = %q, want %q", notSupported.Capability, "PIPELINING")
+	}
+}
@@ -0,0 +1,158 @@
+package pop3
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"time"
+)
+
+// Logger is the minimal logging interface accepted by WithLogger.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Option configures a Dial, DialTLS, DialContext or NewClient call.
+type Option func(*options)
+
+type options struct {
+	dialer    *net.Dialer
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	logger    Logger
+}
+
+func resolveOptions(opts []Option) *options {
+	o := &options{dialer: &net.Dialer{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTimeout sets a deadline applied around every subsequent command
+// issued through Cmd.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithTLSConfig sets the tls.Config used by DialTLS.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) { o.tlsConfig = config }
+}
+
+// WithDialer sets the net.Dialer used to establish the connection,
+// letting callers set a connect timeout or bind address.
+func WithDialer(dialer *net.Dialer) Option {
+	return func(o *options) { o.dialer = dialer }
+}
+
+// WithLogger sets a logger that receives one line per command sent to the
+// server. Note that commands such as USER/PASS/APOP carry credentials in
+// plain text, so logged output should be treated as sensitive.
+func WithLogger(logger Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// DialContext connects to address, honoring ctx's deadline and
+// cancellation for both the connect and the initial greeting.
+func DialContext(ctx context.Context, address string, opts ...Option) (c *Client, err error) {
+	o := resolveOptions(opts)
+	conn, err := o.dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return
+		}
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	type result struct {
+		c   *Client
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		nc, nerr := NewClient(conn, opts...)
+		done <- result{nc, nerr}
+	}()
+
+	select {
+	case r := <-done:
+		return r.c, r.err
+	case <-ctx.Done():
+		conn.SetDeadline(time.Now())
+		// conn is still being used by the NewClient goroutine above, so
+		// wait for it to give up before closing; whether it succeeded or
+		// failed, nothing will read the client it produced.
+		<-done
+		conn.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// CmdContext is like Cmd but honors ctx's deadline and cancellation,
+// aborting the command if ctx is done before the server replies.
+func (c *Client) CmdContext(ctx context.Context, format string, args ...interface{}) (line string, err error) {
+	err = c.withDeadline(ctx, c.conn, func() error {
+		var cmdErr error
+		line, cmdErr = c.Cmd(format, args...)
+		return cmdErr
+	})
+	return
+}
+
+// RetrContext is like Retr but honors ctx's deadline and cancellation.
+func (c *Client) RetrContext(ctx context.Context, msg int) (m *mail.Message, err error) {
+	err = c.withDeadline(ctx, c.conn, func() error {
+		var retrErr error
+		m, retrErr = c.Retr(msg)
+		return retrErr
+	})
+	return
+}
+
+// withDeadline runs fn with conn's deadline bound to ctx: if ctx carries a
+// deadline, or c.timeout is set, the earlier of the two is applied to
+// conn, and if ctx is canceled before fn returns, conn's deadline is
+// forced into the past so the in-flight read or write unblocks with an
+// error. The deadline is always reset to none before returning, even when
+// ctx has no fixed deadline, so a cancellation never leaves conn stuck
+// with a deadline in the past.
+//
+// c.deadlineManaged is set for the duration of fn so that Cmd does not
+// also apply c.timeout itself and clobber the deadline set here.
+func (c *Client) withDeadline(ctx context.Context, conn net.Conn, fn func() error) error {
+	defer conn.SetDeadline(time.Time{})
+
+	deadline, ok := ctx.Deadline()
+	if c.timeout > 0 {
+		if timeoutDeadline := time.Now().Add(c.timeout); !ok || timeoutDeadline.Before(deadline) {
+			deadline, ok = timeoutDeadline, true
+		}
+	}
+	if ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	c.deadlineManaged = true
+	defer func() { c.deadlineManaged = false }()
+
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		conn.SetDeadline(time.Now())
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,54 @@
+package pop3
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+// TestCmdContextCancelDoesNotBrickConnection guards against a regression
+// where canceling a context with no fixed Deadline (context.WithCancel)
+// left the underlying conn's deadline stuck in the past forever, failing
+// every later command even outside of any context.
+func TestCmdContextCancelDoesNotBrickConnection(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	go serverConn.Write([]byte("+OK ready\r\n"))
+
+	c, err := NewClient(clientConn)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	// Read the command bytes (so Send completes) but never reply,
+	// simulating a server that stalls mid-request.
+	commandRead := make(chan struct{})
+	go func() {
+		buf := make([]byte, 64)
+		serverConn.Read(buf)
+		close(commandRead)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-commandRead
+		cancel()
+	}()
+
+	if _, err := c.CmdContext(ctx, "%s\r\n", NOOP); err != context.Canceled {
+		t.Fatalf("CmdContext err = %v, want context.Canceled", err)
+	}
+
+	// The connection must still be usable: a fresh, context-free command
+	// should succeed rather than failing with a stale i/o timeout.
+	go func() {
+		buf := make([]byte, 64)
+		serverConn.Read(buf)
+		serverConn.Write([]byte("+OK\r\n"))
+	}()
+
+	if _, err := c.Cmd("%s\r\n", NOOP); err != nil {
+		t.Fatalf("Cmd after canceled CmdContext: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package pop3
+
+import "regexp"
+
+// ServerError represents a "-ERR" response from the server, including the
+// RFC 2449 extended response code when the server provides one, e.g.
+// "-ERR [IN-USE] Unable to lock maildrop: retry later".
+type ServerError struct {
+	// Line is the raw response line as sent by the server.
+	Line string
+
+	// Code is the bracketed extended response code, if any, such as
+	// "IN-USE", "LOGIN-DELAY", "AUTH", "SYS/PERM" or "SYS/TEMP".
+	Code string
+}
+
+var serverErrorCodeRe = regexp.MustCompile(`^-ERR \[([^\]]+)\]`)
+
+func newServerError(line string) *ServerError {
+	e := &ServerError{Line: line}
+	if m := serverErrorCodeRe.FindStringSubmatch(line); m != nil {
+		e.Code = m[1]
+	}
+	return e
+}
+
+func (e *ServerError) Error() string {
+	return "pop3: " + e.Line
+}
+
+// IsAuthFailure reports whether err is a *ServerError carrying the RFC
+// 2449 [AUTH] code, indicating rejected credentials rather than, say, a
+// locked maildrop.
+func IsAuthFailure(err error) bool {
+	se, ok := err.(*ServerError)
+	return ok && se.Code == "AUTH"
+}
+
+// IsInUse reports whether err is a *ServerError carrying the RFC 2449
+// [IN-USE] code, indicating the maildrop is locked by another session.
+func IsInUse(err error) bool {
+	se, ok := err.(*ServerError)
+	return ok && se.Code == "IN-USE"
+}
@@ -0,0 +1,52 @@
+package pop3
+
+import "testing"
+
+func TestNewServerErrorParsesCode(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantCode string
+	}{
+		{"-ERR [IN-USE] Unable to lock maildrop: retry later", "IN-USE"},
+		{"-ERR [AUTH] authentication failed", "AUTH"},
+		{"-ERR [SYS/TEMP] please try again later", "SYS/TEMP"},
+		{"-ERR permission denied", ""},
+	}
+
+	for _, tt := range tests {
+		e := newServerError(tt.line)
+		if e.Line != tt.line {
+			t.Errorf("newServerError(%q).Line = %q, want %q", tt.line, e.Line, tt.line)
+		}
+		if e.Code != tt.wantCode {
+			t.Errorf("newServerError(%q).Code = %q, want %q", tt.line, e.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestIsAuthFailureAndIsInUse(t *testing.T) {
+	authErr := newServerError("-ERR [AUTH] authentication failed")
+	if !IsAuthFailure(authErr) {
+		t.Error("IsAuthFailure(AUTH error) = false, want true")
+	}
+	if IsInUse(authErr) {
+		t.Error("IsInUse(AUTH error) = true, want false")
+	}
+
+	inUseErr := newServerError("-ERR [IN-USE] Unable to lock maildrop: retry later")
+	if !IsInUse(inUseErr) {
+		t.Error("IsInUse(IN-USE error) = false, want true")
+	}
+	if IsAuthFailure(inUseErr) {
+		t.Error("IsAuthFailure(IN-USE error) = true, want false")
+	}
+
+	if IsAuthFailure(nil) || IsInUse(nil) {
+		t.Error("IsAuthFailure/IsInUse(nil) = true, want false")
+	}
+
+	plainErr := newServerError("-ERR bad news")
+	if IsAuthFailure(plainErr) || IsInUse(plainErr) {
+		t.Error("IsAuthFailure/IsInUse(uncoded error) = true, want false")
+	}
+}
@@ -0,0 +1,78 @@
+package pop3
+
+import (
+	"fmt"
+	"net/mail"
+)
+
+// Mailbox layers a stateful, UIDL-based sync API over a Client, matching
+// the poll-and-fetch pattern most consumers of this package otherwise
+// re-implement by hand.
+type Mailbox struct {
+	c *Client
+}
+
+// NewMailbox wraps c in a Mailbox.
+func NewMailbox(c *Client) *Mailbox {
+	return &Mailbox{c: c}
+}
+
+// ForEachNew fetches the maildrop's UIDL listing, skips any message whose
+// UID is already present in seenUIDs, and streams each remaining message
+// via RetrReader before calling fn with its UID and parsed contents. fn's
+// msg.Body is still backed by the live connection, so fn must finish
+// reading it before returning: RetrReader is closed as soon as fn returns,
+// draining any unread lines so the connection is ready for the next
+// message. This keeps ForEachNew usable on gigabyte mailboxes without
+// holding a whole message in memory; callers that do need the body after
+// fn returns should read it to a buffer themselves before returning.
+// seenUIDs is read-only here; callers should record each uid fn is called
+// with once it has been durably processed.
+func (mb *Mailbox) ForEachNew(seenUIDs map[string]bool, fn func(uid string, msg *mail.Message) error) error {
+	uidls, err := mb.c.UidlAll()
+	if err != nil {
+		return err
+	}
+
+	for _, u := range uidls {
+		if seenUIDs[u.Uid] {
+			continue
+		}
+
+		r, err := mb.c.RetrReader(u.Id)
+		if err != nil {
+			return err
+		}
+
+		msg, err := mail.ReadMessage(r)
+		if err != nil {
+			r.Close()
+			return err
+		}
+
+		fnErr := fn(u.Uid, msg)
+		if closeErr := r.Close(); fnErr == nil {
+			fnErr = closeErr
+		}
+		if fnErr != nil {
+			return fnErr
+		}
+	}
+	return nil
+}
+
+// DeleteByUID translates uid back to the current session's message number
+// via UidlAll and marks it for deletion.
+func (mb *Mailbox) DeleteByUID(uid string) error {
+	uidls, err := mb.c.UidlAll()
+	if err != nil {
+		return err
+	}
+
+	for _, u := range uidls {
+		if u.Uid == uid {
+			return mb.c.Dele(u.Id)
+		}
+	}
+	return fmt.Errorf("pop3: no message with UID %q", uid)
+}
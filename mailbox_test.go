@@ -0,0 +1,168 @@
+package pop3
+
+import (
+	"bufio"
+	"io"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// TestForEachNewBodyReadableInCallback guards against a regression where
+// RetrReader's connection-backed reader was closed (draining the rest of
+// the message) before fn ran, leaving fn with an already-exhausted
+// msg.Body every time.
+func TestForEachNewBodyReadableInCallback(t *testing.T) {
+	// Body is read from msg.Body inside fn, exercising the streaming path:
+	// msg.Body is still backed by the live connection at this point, not a
+	// buffer, so fn must do its own reading before returning.
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "UIDL\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("1 uid-1\r\n"))
+		server.Write([]byte(".\r\n"))
+
+		server.Read(buf) // consume "RETR 1\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("Subject: hi\r\n"))
+		server.Write([]byte("\r\n"))
+		server.Write([]byte("hello body\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	mb := NewMailbox(c)
+
+	var body []byte
+	var gotUID string
+	err := mb.ForEachNew(nil, func(uid string, msg *mail.Message) error {
+		gotUID = uid
+		var readErr error
+		body, readErr = io.ReadAll(msg.Body)
+		return readErr
+	})
+	if err != nil {
+		t.Fatalf("ForEachNew: %v", err)
+	}
+
+	if gotUID != "uid-1" {
+		t.Fatalf("uid = %q, want %q", gotUID, "uid-1")
+	}
+	if want := "hello body\r\n"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
+
+// TestForEachNewDrainsUnreadBody verifies that a message left partially
+// unread by fn doesn't brick the connection for the next RETR: RetrReader
+// must still drain to the terminator once fn returns.
+func TestForEachNewDrainsUnreadBody(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "UIDL\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("1 uid-1\r\n"))
+		server.Write([]byte("2 uid-2\r\n"))
+		server.Write([]byte(".\r\n"))
+
+		server.Read(buf) // consume "RETR 1\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("\r\n"))
+		server.Write([]byte("line one\r\n"))
+		server.Write([]byte("line two\r\n"))
+		server.Write([]byte(".\r\n"))
+
+		server.Read(buf) // consume "RETR 2\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("\r\n"))
+		server.Write([]byte("second message\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	mb := NewMailbox(c)
+
+	var seen []string
+	err := mb.ForEachNew(nil, func(uid string, msg *mail.Message) error {
+		seen = append(seen, uid)
+		// Deliberately don't read msg.Body for uid-1, to verify
+		// ForEachNew still drains it before moving on to uid-2.
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachNew: %v", err)
+	}
+
+	if want := []string{"uid-1", "uid-2"}; len(seen) != 2 || seen[0] != want[0] || seen[1] != want[1] {
+		t.Fatalf("seen = %v, want %v", seen, want)
+	}
+}
+
+// TestForEachNewSkipsSeen verifies that messages whose UID is already in
+// seenUIDs are never fetched or passed to fn.
+func TestForEachNewSkipsSeen(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "UIDL\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("1 uid-1\r\n"))
+		server.Write([]byte("2 uid-2\r\n"))
+		server.Write([]byte(".\r\n"))
+
+		server.Read(buf) // consume "RETR 2\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	mb := NewMailbox(c)
+
+	var called []string
+	err := mb.ForEachNew(map[string]bool{"uid-1": true}, func(uid string, msg *mail.Message) error {
+		called = append(called, uid)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachNew: %v", err)
+	}
+
+	if want := []string{"uid-2"}; len(called) != 1 || called[0] != want[0] {
+		t.Fatalf("called = %v, want %v", called, want)
+	}
+}
+
+// TestDeleteByUID verifies the UID is translated back to the session's
+// message number before issuing DELE.
+func TestDeleteByUID(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "UIDL\r\n"
+		server.Write([]byte("+OK\r\n"))
+		server.Write([]byte("1 uid-1\r\n"))
+		server.Write([]byte("2 uid-2\r\n"))
+		server.Write([]byte(".\r\n"))
+
+		line, _ := bufio.NewReader(server).ReadString('\n')
+		const want = "DELE 2"
+		if got := strings.TrimRight(line, "\r\n"); got != want {
+			t.Errorf("server received %q, want %q", got, want)
+		}
+		server.Write([]byte("+OK\r\n"))
+	}()
+
+	mb := NewMailbox(c)
+	if err := mb.DeleteByUID("uid-2"); err != nil {
+		t.Fatalf("DeleteByUID: %v", err)
+	}
+}
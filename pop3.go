@@ -4,6 +4,7 @@ package pop3
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -12,32 +13,97 @@ import (
 	"net/mail"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// Command strings as defined by RFC 1939.
+const (
+	USER = "USER"
+	PASS = "PASS"
+	STAT = "STAT"
+	LIST = "LIST"
+	RETR = "RETR"
+	DELE = "DELE"
+	NOOP = "NOOP"
+	RSET = "RSET"
+	QUIT = "QUIT"
+	TOP  = "TOP"
+	UIDL = "UIDL"
+	CAPA = "CAPA"
+)
+
+// Client represents a POP3 client connection.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+
+	// capabilities holds the server's advertised CAPA response, if any
+	// has been fetched yet. A nil map means CAPA has not been queried.
+	capabilities map[string]bool
+
+	// greeting holds the server's banner line, captured so APOP can pull
+	// the timestamp out of it.
+	greeting string
+
+	// timeout, if non-zero, is applied as a deadline around every command.
+	timeout time.Duration
+
+	// logger, if set, receives one line per command sent to the server.
+	logger Logger
+
+	// deadlineManaged is set by withDeadline for the duration of a
+	// *Context call, so that Cmd's own timeout handling below doesn't
+	// clobber the deadline withDeadline already derived from ctx.
+	deadlineManaged bool
+}
+
+// MessageList contains a message's non unique id and its size.
+type MessageList struct {
+	Id   int
+	Size int
+}
+
+// MessageUidl contains a message's non unique id and its unique id.
+type MessageUidl struct {
+	Id  int
+	Uid string
+}
+
+// IsOK reports whether line is a positive "+OK" server response.
+func IsOK(line string) bool {
+	return strings.HasPrefix(line, "+OK")
+}
+
 // Dial connects to the address on the named network.
-func Dial(address string) (c *Client, err error) {
-	conn, err := net.Dial("tcp", address)
+func Dial(address string, opts ...Option) (c *Client, err error) {
+	o := resolveOptions(opts)
+	conn, err := o.dialer.Dial("tcp", address)
 	if err != nil {
 		return
 	}
-	return NewClient(conn)
+	return NewClient(conn, opts...)
 }
 
 // DialTLS connects to the address on the named network using tls.
-func DialTLS(address string) (c *Client, err error) {
-	conn, err := tls.Dial("tcp", address, nil)
+func DialTLS(address string, opts ...Option) (c *Client, err error) {
+	o := resolveOptions(opts)
+	conn, err := tls.DialWithDialer(o.dialer, "tcp", address, o.tlsConfig)
 	if err != nil {
 		return
 	}
-	return NewClient(conn)
+	return NewClient(conn, opts...)
 }
 
 // NewClient returns a new client object using an existing connection.
-func NewClient(conn net.Conn) (c *Client, err error) {
+func NewClient(conn net.Conn, opts ...Option) (c *Client, err error) {
+	o := resolveOptions(opts)
 	c = &Client{
-		conn: conn,
-		r:    bufio.NewReader(conn),
-		w:    bufio.NewWriter(conn),
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		w:       bufio.NewWriter(conn),
+		timeout: o.timeout,
+		logger:  o.logger,
 	}
 
 	// Make sure we receive the server greeting
@@ -48,6 +114,7 @@ func NewClient(conn net.Conn) (c *Client, err error) {
 	if !IsOK(line) {
 		return nil, errors.New("pop3: Server did not respond with +OK")
 	}
+	c.greeting = line
 	return
 }
 
@@ -85,15 +152,30 @@ func (c *Client) ReadLines() (lines []string, err error) {
 // Send writes a command to the buffer and flushes it. Does not return any
 // lines from the buffer.
 func (c *Client) Send(format string, args ...interface{}) (err error) {
-	if _, err = c.w.WriteString(fmt.Sprintf(format, args...)); err != nil {
+	cmd := fmt.Sprintf(format, args...)
+	if c.logger != nil {
+		c.logger.Printf("pop3: -> %s", strings.TrimRight(cmd, "\r\n"))
+	}
+
+	if _, err = c.w.WriteString(cmd); err != nil {
 		return
 	}
 	return c.w.Flush()
 }
 
-// Cmd sends a command to the server and returns a single line from the buffer.
+// Cmd sends a command to the server and returns a single line from the
+// buffer. If a timeout was configured via WithTimeout, it is applied as a
+// deadline around the round trip, unless a *Context caller (CmdContext,
+// RetrContext, ...) has already derived and applied one for this call.
 func (c *Client) Cmd(format string,
 	args ...interface{}) (line string, err error) {
+	if c.timeout > 0 && !c.deadlineManaged {
+		if err = c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return
+		}
+		defer c.conn.SetDeadline(time.Time{})
+	}
+
 	if err = c.Send(format, args...); err != nil {
 		return
 	}
@@ -103,7 +185,7 @@ func (c *Client) Cmd(format string,
 		return
 	}
 	if !IsOK(line) {
-		return "", errors.New("pop3: Server did not respond with +OK")
+		return "", newServerError(line)
 	}
 	return
 }
@@ -180,7 +262,7 @@ func (c *Client) Stat() (count, size int, err error) {
 // List returns the MessageList object which contains the message non unique
 // id and its size.
 func (c *Client) List(msg int) (list MessageList, err error) {
-	line, err := c.Cmd("%s %s\r\n", LIST, msg)
+	line, err := c.Cmd("%s %d\r\n", LIST, msg)
 	if err != nil {
 		return
 	}
@@ -223,36 +305,34 @@ func (c *Client) ListAll() (list []MessageList, err error) {
 	return
 }
 
-// Retr downloads the given message and returns it as a mail.Message object.
-func (c *Client) Retr(msg int) (m string, err error) {
-	if _, err = c.Cmd("%s %s\r\n", RETR, msg); err != nil {
-		return
-	}
-
-	m, err = c.r
+// Retr downloads the given message and returns it as a mail.Message object
+// whose Body has already been read off the wire. For large messages,
+// prefer RetrReader or RetrTo so the body can be streamed instead of held
+// in memory: mail.Message.Body is a plain io.Reader with no Close method,
+// so there is no way to hand the caller a Body still backed by the live
+// connection without either leaving the connection undrained or closing
+// it under the caller's feet before they read it.
+func (c *Client) Retr(msg int) (m *mail.Message, err error) {
+	r, err := c.RetrReader(msg)
 	if err != nil {
 		return
 	}
 
-	// mail.ReadMessage does not consume the message end dot in the buffer
-	// so we must move the buffer along. Need to find a better way of
-	// doing this.
-	line, err := c.ReadLine()
+	body, err := io.ReadAll(r)
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return
 	}
-	if line != "." {
-		if err = c.r.UnreadByte(); err != nil {
-			return
-		}
-	}
-	return
+
+	return mail.ReadMessage(bytes.NewReader(body))
 }
 
 // Dele will delete the given message from the maildrop.
 // Changes will only take affect after the Quit command is issued.
 func (c *Client) Dele(msg int) (err error) {
-	if _, err = c.Cmd("%s %s\r\n", DELE, msg); err != nil {
+	if _, err = c.Cmd("%s %d\r\n", DELE, msg); err != nil {
 		return
 	}
 	return
@@ -278,6 +358,10 @@ func (c *Client) Rset() (err error) {
 // Top will return a varible number of lines for a given message as a
 // mail.Message object.
 func (c *Client) Top(msg int, n int) (m *mail.Message, err error) {
+	if err = c.requireCapability("TOP"); err != nil {
+		return
+	}
+
 	if _, err = c.Cmd("%s %d %d\r\n", TOP, msg, n); err != nil {
 		return
 	}
@@ -306,7 +390,11 @@ func (c *Client) Top(msg int, n int) (m *mail.Message, err error) {
 // Uidl will return a MessageUidl object which contains the message non
 // unique id and a unique id.
 func (c *Client) Uidl(msg int) (list MessageUidl, err error) {
-	line, err := c.Cmd("%s %s\r\n", UIDL, msg)
+	if err = c.requireCapability("UIDL"); err != nil {
+		return
+	}
+
+	line, err := c.Cmd("%s %d\r\n", UIDL, msg)
 	if err != nil {
 		return
 	}
@@ -321,6 +409,10 @@ func (c *Client) Uidl(msg int) (list MessageUidl, err error) {
 // UidlAll will return a MessageUidl object which contains all messages in
 // the maildrop.
 func (c *Client) UidlAll() (list []MessageUidl, err error) {
+	if err = c.requireCapability("UIDL"); err != nil {
+		return
+	}
+
 	if _, err = c.Cmd("%s\r\n", UIDL); err != nil {
 		return
 	}
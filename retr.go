@@ -0,0 +1,82 @@
+package pop3
+
+import (
+	"io"
+	"strings"
+)
+
+// RetrReader sends RETR for msg and returns a reader over its body. The
+// reader transparently removes RFC 1939 §3.3 byte-stuffing (a line that
+// begins with ".." has its first "." removed; other lines are passed
+// through unchanged) and reads up to, but not including, the terminating
+// "." line.
+//
+// If the caller does not read the body to completion, Close drains the
+// remaining lines up to the terminator so the connection is left usable
+// for the next command.
+func (c *Client) RetrReader(msg int) (io.ReadCloser, error) {
+	if _, err := c.Cmd("%s %d\r\n", RETR, msg); err != nil {
+		return nil, err
+	}
+	return &dotReader{c: c}, nil
+}
+
+// RetrTo sends RETR for msg and copies its body to w, without holding the
+// whole message in memory. It is intended for large messages.
+func (c *Client) RetrTo(msg int, w io.Writer) (err error) {
+	r, err := c.RetrReader(msg)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return
+}
+
+// dotReader adapts the server's dot-stuffed, dot-terminated message body
+// to an io.ReadCloser.
+type dotReader struct {
+	c    *Client
+	buf  []byte
+	done bool
+}
+
+func (d *dotReader) Read(p []byte) (n int, err error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+
+		line, err := d.c.ReadLine()
+		if err != nil {
+			d.done = true
+			return 0, err
+		}
+		if line == "." {
+			d.done = true
+			return 0, io.EOF
+		}
+		if strings.HasPrefix(line, "..") {
+			line = line[1:]
+		}
+		d.buf = append([]byte(line), '\r', '\n')
+	}
+
+	n = copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// Close drains any unread lines up to the terminating dot, leaving the
+// connection ready for the next command.
+func (d *dotReader) Close() error {
+	for !d.done {
+		line, err := d.c.ReadLine()
+		if err != nil || line == "." {
+			d.done = true
+			return err
+		}
+	}
+	return nil
+}
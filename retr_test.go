@@ -0,0 +1,122 @@
+package pop3
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func newTestClient(t *testing.T) (*Client, net.Conn) {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+	go serverConn.Write([]byte("+OK ready\r\n"))
+
+	c, err := NewClient(clientConn)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c, serverConn
+}
+
+func TestRetrReaderUnstuffsDots(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "RETR 1\r\n"
+		server.Write([]byte("+OK 2 octets\r\n"))
+		server.Write([]byte("..leading dot\r\n"))
+		server.Write([]byte("plain line\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	r, err := c.RetrReader(1)
+	if err != nil {
+		t.Fatalf("RetrReader: %v", err)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := ".leading dot\r\nplain line\r\n"
+	if string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
+
+func TestRetrReaderCloseDrainsUnreadLines(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "RETR 1\r\n"
+		server.Write([]byte("+OK 2 octets\r\n"))
+		server.Write([]byte("first line\r\n"))
+		server.Write([]byte("second line\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	r, err := c.RetrReader(1)
+	if err != nil {
+		t.Fatalf("RetrReader: %v", err)
+	}
+
+	buf := make([]byte, 4)
+	if _, err := r.Read(buf); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	// Close before reading the rest of the body; it must drain up to the
+	// terminator so the connection is left usable for the next command.
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf)
+		server.Write([]byte("+OK\r\n"))
+	}()
+
+	if err := c.Noop(); err != nil {
+		t.Fatalf("Noop after partial Retr read: %v", err)
+	}
+}
+
+func TestRetrBodyReadableAfterReturn(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		buf := make([]byte, 64)
+		server.Read(buf) // consume "RETR 1\r\n"
+		server.Write([]byte("+OK 2 octets\r\n"))
+		server.Write([]byte("Subject: hi\r\n"))
+		server.Write([]byte("\r\n"))
+		server.Write([]byte("hello\r\n"))
+		server.Write([]byte(".\r\n"))
+	}()
+
+	m, err := c.Retr(1)
+	if err != nil {
+		t.Fatalf("Retr: %v", err)
+	}
+
+	body, err := io.ReadAll(m.Body)
+	if err != nil {
+		t.Fatalf("reading m.Body after Retr returned: %v", err)
+	}
+	if want := "hello\r\n"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+	if got := m.Header.Get("Subject"); got != "hi" {
+		t.Fatalf("Subject = %q, want %q", got, "hi")
+	}
+}
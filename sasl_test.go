@@ -0,0 +1,172 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readServerLine reads a single CRLF-terminated line sent by the client,
+// stripping the terminator.
+func readServerLine(t *testing.T, server net.Conn) string {
+	t.Helper()
+	line, err := bufio.NewReader(server).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading line from client: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func TestAuthSASLPlainSendsInitialResponse(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		done <- readServerLine(t, server)
+		server.Write([]byte("+OK\r\n"))
+	}()
+
+	if err := c.AuthSASL(PlainAuth("", "user", "pass")); err != nil {
+		t.Fatalf("AuthSASL: %v", err)
+	}
+
+	wantInitial := base64.StdEncoding.EncodeToString([]byte("\x00user\x00pass"))
+	if got := <-done; got != "AUTH PLAIN "+wantInitial {
+		t.Fatalf("server received %q, want %q", got, "AUTH PLAIN "+wantInitial)
+	}
+}
+
+func TestAuthSASLLoginTwoStepChallenge(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	r := bufio.NewReader(server)
+	go func() {
+		line, _ := r.ReadString('\n')
+		if got := strings.TrimRight(line, "\r\n"); got != "AUTH LOGIN" {
+			t.Errorf("server received %q, want %q", got, "AUTH LOGIN")
+		}
+		server.Write([]byte("+ " + base64.StdEncoding.EncodeToString([]byte("Username:")) + "\r\n"))
+
+		line, _ = r.ReadString('\n')
+		got, _ := base64.StdEncoding.DecodeString(strings.TrimRight(line, "\r\n"))
+		if string(got) != "user" {
+			t.Errorf("username response = %q, want %q", got, "user")
+		}
+		server.Write([]byte("+ " + base64.StdEncoding.EncodeToString([]byte("Password:")) + "\r\n"))
+
+		line, _ = r.ReadString('\n')
+		got, _ = base64.StdEncoding.DecodeString(strings.TrimRight(line, "\r\n"))
+		if string(got) != "pass" {
+			t.Errorf("password response = %q, want %q", got, "pass")
+		}
+		server.Write([]byte("+OK\r\n"))
+	}()
+
+	if err := c.AuthSASL(LoginAuth("user", "pass")); err != nil {
+		t.Fatalf("AuthSASL: %v", err)
+	}
+}
+
+func TestAuthSASLCramMD5RespondsWithHMAC(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	r := bufio.NewReader(server)
+	challenge := "<1896.697170952@dbc.mtview.ca.us>"
+	go func() {
+		line, _ := r.ReadString('\n')
+		if got := strings.TrimRight(line, "\r\n"); got != "AUTH CRAM-MD5" {
+			t.Errorf("server received %q, want %q", got, "AUTH CRAM-MD5")
+		}
+		server.Write([]byte("+ " + base64.StdEncoding.EncodeToString([]byte(challenge)) + "\r\n"))
+
+		line, _ = r.ReadString('\n')
+		got, _ := base64.StdEncoding.DecodeString(strings.TrimRight(line, "\r\n"))
+
+		mac := hmac.New(md5.New, []byte("pass"))
+		mac.Write([]byte(challenge))
+		want := fmt.Sprintf("user %x", mac.Sum(nil))
+		if string(got) != want {
+			t.Errorf("CRAM-MD5 response = %q, want %q", got, want)
+		}
+		server.Write([]byte("+OK\r\n"))
+	}()
+
+	if err := c.AuthSASL(CRAMMD5Auth("user", "pass")); err != nil {
+		t.Fatalf("AuthSASL: %v", err)
+	}
+}
+
+func TestAuthSASLXOAuth2SendsBearerToken(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		done <- readServerLine(t, server)
+		server.Write([]byte("+OK\r\n"))
+	}()
+
+	if err := c.AuthSASL(XOAuth2Auth("user", "token")); err != nil {
+		t.Fatalf("AuthSASL: %v", err)
+	}
+
+	wantInitial := base64.StdEncoding.EncodeToString([]byte("user=user\x01auth=Bearer token\x01\x01"))
+	if got := <-done; got != "AUTH XOAUTH2 "+wantInitial {
+		t.Fatalf("server received %q, want %q", got, "AUTH XOAUTH2 "+wantInitial)
+	}
+}
+
+func TestAuthSASLServerErrAborts(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+
+	go func() {
+		readServerLine(t, server)
+		server.Write([]byte("-ERR [AUTH] authentication failed\r\n"))
+	}()
+
+	err := c.AuthSASL(PlainAuth("", "user", "wrong"))
+	if !IsAuthFailure(err) {
+		t.Fatalf("AuthSASL err = %v, want an AUTH ServerError", err)
+	}
+}
+
+// TestAuthSASLHonorsTimeout guards against a regression where AuthSASL
+// bypassed Cmd entirely and so never applied the deadline WithTimeout
+// configures, letting a stalling server hang the exchange forever.
+func TestAuthSASLHonorsTimeout(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	go serverConn.Write([]byte("+OK ready\r\n"))
+
+	c, err := NewClient(clientConn, WithTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	// Consume the AUTH line but never reply, simulating a server that
+	// stalls mid-exchange.
+	go func() {
+		buf := make([]byte, 64)
+		serverConn.Read(buf)
+	}()
+
+	start := time.Now()
+	err = c.AuthSASL(PlainAuth("", "user", "pass"))
+	if err == nil {
+		t.Fatal("AuthSASL err = nil, want a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("AuthSASL took %v, want it to time out around 50ms", elapsed)
+	}
+}
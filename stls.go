@@ -0,0 +1,52 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/tls"
+)
+
+// STLS is the RFC 2595 command used to request a TLS upgrade on an
+// otherwise plaintext connection.
+const STLS = "STLS"
+
+// StartTLS sends the STLS command over the existing plaintext connection
+// and, on success, wraps it with TLS. config may be nil, in which case
+// Go's default tls.Config is used; that config sets neither ServerName nor
+// InsecureSkipVerify, so the TLS handshake then fails with an error.
+//
+// Per RFC 2595, any capabilities learned via a prior Capa call are
+// discarded: a server may advertise different capabilities once the
+// session is encrypted, so callers should call Capa again if needed.
+func (c *Client) StartTLS(config *tls.Config) (err error) {
+	if _, err = c.Cmd("%s\r\n", STLS); err != nil {
+		return
+	}
+
+	tlsConn := tls.Client(c.conn, config)
+	if err = tlsConn.Handshake(); err != nil {
+		return
+	}
+
+	c.conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	c.w = bufio.NewWriter(tlsConn)
+	c.capabilities = nil
+	return
+}
+
+// DialStartTLS connects to address in plaintext, reads the server greeting
+// and immediately upgrades the connection to TLS via STLS. This is needed
+// for servers that only listen on port 110 and rely on STLS rather than
+// implicit TLS on port 995. opts are the same Dial options accepted by
+// Dial, e.g. WithTimeout or WithDialer.
+func DialStartTLS(address string, config *tls.Config, opts ...Option) (c *Client, err error) {
+	c, err = Dial(address, opts...)
+	if err != nil {
+		return
+	}
+
+	if err = c.StartTLS(config); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,133 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateTestCert returns a self-signed certificate for localhost, valid
+// for the duration of a single test run.
+func generateTestCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// runStlsServer starts a single-connection plaintext POP3 server that
+// greets the client, answers STLS, and upgrades the connection with cert,
+// then answers one NOOP over the resulting TLS session. It returns the
+// address to dial.
+func runStlsServer(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("+OK ready\r\n"))
+
+		r := bufio.NewReader(conn)
+		line, _ := r.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") != "STLS" {
+			return
+		}
+		conn.Write([]byte("+OK\r\n"))
+
+		tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+
+		tr := bufio.NewReader(tlsConn)
+		line, _ = tr.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") != "NOOP" {
+			return
+		}
+		tlsConn.Write([]byte("+OK\r\n"))
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestStartTLSUpgradesConnection(t *testing.T) {
+	cert := generateTestCert(t)
+	addr := runStlsServer(t, cert)
+
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Quit()
+
+	c.capabilities = map[string]bool{"STLS": true}
+
+	if err := c.StartTLS(&tls.Config{InsecureSkipVerify: true}); err != nil {
+		t.Fatalf("StartTLS: %v", err)
+	}
+	if c.capabilities != nil {
+		t.Fatal("capabilities not cleared after StartTLS")
+	}
+	if _, ok := c.conn.(*tls.Conn); !ok {
+		t.Fatalf("c.conn = %T, want *tls.Conn", c.conn)
+	}
+
+	if err := c.Noop(); err != nil {
+		t.Fatalf("Noop over TLS: %v", err)
+	}
+}
+
+func TestDialStartTLSForwardsOptions(t *testing.T) {
+	cert := generateTestCert(t)
+	addr := runStlsServer(t, cert)
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	c, err := DialStartTLS(addr, &tls.Config{InsecureSkipVerify: true}, WithDialer(dialer), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("DialStartTLS: %v", err)
+	}
+	defer c.Quit()
+
+	if c.timeout != time.Second {
+		t.Fatalf("c.timeout = %v, want %v (WithTimeout option not forwarded)", c.timeout, time.Second)
+	}
+	if err := c.Noop(); err != nil {
+		t.Fatalf("Noop after DialStartTLS: %v", err)
+	}
+}